@@ -0,0 +1,51 @@
+// Package chatcompletion bridges a streaming chat completion (however
+// it is produced: an LLM SDK's streaming response, a local model
+// runner, etc.) to pubsub.ChatStreamPublisher, so the frontend and
+// third-party subscribers can render tokens as they arrive instead of
+// polling.
+package chatcompletion
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/pubsub"
+)
+
+// Chunk is a single piece of streamed model output.
+type Chunk struct {
+	Content string
+	// Err, if set, ends the stream: StreamAndPublish publishes
+	// ChatEventKindMessageError with Err and returns it instead of
+	// reading any further chunks.
+	Err error
+}
+
+// StreamAndPublish drains chunks (as produced by whatever client is
+// fulfilling the completion for messageID) and publishes a
+// message_token_delta ChatEvent per chunk via pub, followed by a
+// message_completed event carrying the full assembled content, or a
+// message_error event if the stream ends with a Chunk.Err set.
+func StreamAndPublish(ctx context.Context, pub *pubsub.ChatStreamPublisher, messageID uuid.UUID, role string, chunks <-chan Chunk) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				return pub.PublishCompleted(ctx, messageID, role)
+			}
+			if chunk.Err != nil {
+				if err := pub.PublishError(ctx, messageID, role, chunk.Err); err != nil {
+					return xerrors.Errorf("publish chat message error: %w", err)
+				}
+				return chunk.Err
+			}
+			if err := pub.PublishTokenDelta(ctx, messageID, role, chunk.Content); err != nil {
+				return xerrors.Errorf("publish chat message token delta: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package chatcompletion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/pubsub"
+)
+
+type recordingPublisher struct {
+	events []pubsub.ChatEvent
+}
+
+func (r *recordingPublisher) Publish(_ context.Context, _ uuid.UUID, event pubsub.ChatEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestStreamAndPublish_PublishesDeltasThenCompleted(t *testing.T) {
+	t.Parallel()
+
+	rec := &recordingPublisher{}
+	streamPub := pubsub.NewChatStreamPublisher(rec, pubsub.NewChatSequencer(), uuid.New(), uuid.New())
+	messageID := uuid.New()
+
+	chunks := make(chan Chunk, 3)
+	chunks <- Chunk{Content: "Hel"}
+	chunks <- Chunk{Content: "lo"}
+	close(chunks)
+
+	require.NoError(t, StreamAndPublish(context.Background(), streamPub, messageID, "assistant", chunks))
+
+	require.Len(t, rec.events, 3)
+	require.Equal(t, pubsub.ChatEventKindMessageTokenDelta, rec.events[0].Kind)
+	require.Equal(t, "Hel", rec.events[0].Message.Content)
+	require.Equal(t, pubsub.ChatEventKindMessageTokenDelta, rec.events[1].Kind)
+	require.Equal(t, "lo", rec.events[1].Message.Content)
+	require.Equal(t, pubsub.ChatEventKindMessageCompleted, rec.events[2].Kind)
+	require.Equal(t, "Hello", rec.events[2].Message.Content)
+}
+
+func TestStreamAndPublish_StopsOnChunkError(t *testing.T) {
+	t.Parallel()
+
+	rec := &recordingPublisher{}
+	streamPub := pubsub.NewChatStreamPublisher(rec, pubsub.NewChatSequencer(), uuid.New(), uuid.New())
+	messageID := uuid.New()
+
+	wantErr := require.AnError
+	chunks := make(chan Chunk, 2)
+	chunks <- Chunk{Content: "partial"}
+	chunks <- Chunk{Err: wantErr}
+	close(chunks)
+
+	err := StreamAndPublish(context.Background(), streamPub, messageID, "assistant", chunks)
+	require.ErrorIs(t, err, wantErr)
+
+	require.Len(t, rec.events, 2)
+	require.Equal(t, pubsub.ChatEventKindMessageTokenDelta, rec.events[0].Kind)
+	require.Equal(t, pubsub.ChatEventKindMessageError, rec.events[1].Kind)
+	require.Equal(t, wantErr.Error(), rec.events[1].Message.Error)
+}
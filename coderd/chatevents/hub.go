@@ -0,0 +1,129 @@
+package chatevents
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/coderd/database/pubsub"
+	chatpubsub "github.com/coder/coder/v2/coderd/pubsub"
+)
+
+// subscriberBuffer bounds how far a single slow SSE client can fall
+// behind the live pubsub stream before events are dropped for it. The
+// client will still catch up correctly on its next reconnect via the
+// Registry ring buffer and Last-Event-ID.
+const subscriberBuffer = 256
+
+// Hub multiplexes a single pubsub subscription per owner across every
+// locally-attached SSE connection for that owner, and records every
+// event it sees into a Registry so reconnecting clients can replay
+// buffered events instead of missing them.
+type Hub struct {
+	logger   slog.Logger
+	ps       pubsub.Pubsub
+	resolver chatpubsub.PayloadResolver
+	ring     *Registry
+
+	mu     sync.Mutex
+	owners map[uuid.UUID]*ownerHub
+}
+
+type ownerHub struct {
+	cancel      func()
+	subscribers map[chan BufferedEvent]struct{}
+}
+
+// NewHub constructs a Hub backed by ring. resolver may be nil if events
+// are never published through a chatpubsub.CoalescingPublisher.
+func NewHub(logger slog.Logger, ps pubsub.Pubsub, resolver chatpubsub.PayloadResolver, ring *Registry) *Hub {
+	return &Hub{
+		logger:   logger,
+		ps:       ps,
+		resolver: resolver,
+		ring:     ring,
+		owners:   make(map[uuid.UUID]*ownerHub),
+	}
+}
+
+// Subscribe returns a channel that receives every chat event published
+// for ownerID from this point on, starting (and sharing) the owner's
+// underlying pubsub subscription as needed. cutoffSeq is the Registry
+// sequence number of the last event appended before this call: since
+// ring appends and subscriber registration are serialized on the same
+// lock (see broadcast), a caller that replays Registry.Since(ownerID,
+// lastSeq, cutoffSeq) before reading from ch is guaranteed to see every
+// event exactly once, with no gap and no duplicate. The returned
+// unsubscribe func closes ch and tears down the pubsub subscription
+// once it was the last local subscriber.
+func (h *Hub) Subscribe(ownerID uuid.UUID) (ch <-chan BufferedEvent, cutoffSeq int64, unsubscribe func(), err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	oh, ok := h.owners[ownerID]
+	if !ok {
+		oh = &ownerHub{subscribers: make(map[chan BufferedEvent]struct{})}
+		cancel, err := h.ps.SubscribeWithErr(chatpubsub.ChatEventChannel(ownerID), chatpubsub.HandleChatEvent(h.resolver,
+			func(ctx context.Context, event chatpubsub.ChatEvent, err error) {
+				if err != nil {
+					h.logger.Warn(ctx, "chat events hub received error", "error", err)
+					return
+				}
+				h.broadcast(ownerID, event)
+			},
+		))
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		oh.cancel = cancel
+		h.owners[ownerID] = oh
+	}
+
+	subscriber := make(chan BufferedEvent, subscriberBuffer)
+	oh.subscribers[subscriber] = struct{}{}
+
+	return subscriber, h.ring.Head(ownerID), func() { h.unsubscribe(ownerID, subscriber) }, nil
+}
+
+func (h *Hub) unsubscribe(ownerID uuid.UUID, ch chan BufferedEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	oh, ok := h.owners[ownerID]
+	if !ok {
+		return
+	}
+	delete(oh.subscribers, ch)
+	close(ch)
+	if len(oh.subscribers) == 0 {
+		oh.cancel()
+		delete(h.owners, ownerID)
+	}
+}
+
+func (h *Hub) broadcast(ownerID uuid.UUID, event chatpubsub.ChatEvent) {
+	// Appending to the ring and registering a new subscriber (Subscribe)
+	// must be serialized on the same lock: otherwise a subscriber could
+	// be registered after an event is appended but before it is sent
+	// live, receiving it neither via replay (already past lastSeq by the
+	// time it looks) nor live (already broadcast), or the reverse,
+	// receiving it both ways. See Subscribe's doc comment.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buffered := BufferedEvent{Seq: h.ring.Append(ownerID, event), Event: event}
+
+	oh, ok := h.owners[ownerID]
+	if !ok {
+		return
+	}
+	for ch := range oh.subscribers {
+		select {
+		case ch <- buffered:
+		default:
+			h.logger.Warn(context.Background(), "dropping chat event for slow sse subscriber", "owner_id", ownerID)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package chatevents
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/coderd/pubsub"
+)
+
+// ringSize is how many recent events are buffered per owner so a client
+// that reconnects after a brief network blip can catch up on gaps
+// instead of missing events outright.
+const ringSize = 1000
+
+// BufferedEvent pairs a buffered event with the monotonic sequence
+// number it was assigned on arrival.
+type BufferedEvent struct {
+	Seq   int64
+	Event pubsub.ChatEvent
+}
+
+// Registry buffers the last ringSize chat events per owner and assigns
+// each one a per-owner monotonic sequence number, independent of any
+// sequence carried inside the event itself, so SSE clients can resume
+// with Last-Event-ID after a reconnect.
+type Registry struct {
+	mu      sync.Mutex
+	buffers map[uuid.UUID]*ownerRing
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{buffers: make(map[uuid.UUID]*ownerRing)}
+}
+
+// Append records event for ownerID and returns the sequence number it
+// was assigned.
+func (r *Registry) Append(ownerID uuid.UUID, event pubsub.ChatEvent) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.buffers[ownerID]
+	if !ok {
+		ring = &ownerRing{}
+		r.buffers[ownerID] = ring
+	}
+	return ring.append(event)
+}
+
+// Since returns every buffered event for ownerID with a sequence number
+// in (lastSeq, uptoSeq], oldest first. If lastSeq is older than
+// anything still buffered, every buffered event up to uptoSeq is
+// returned; the caller should treat that as a possible gap. uptoSeq
+// exists so a caller that is about to start reading from a live
+// subscription can replay exactly the events the ring held at the
+// moment the subscription was registered (see Hub.Subscribe), without
+// double-delivering anything the live subscription will also receive.
+func (r *Registry) Since(ownerID uuid.UUID, lastSeq, uptoSeq int64) []BufferedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.buffers[ownerID]
+	if !ok {
+		return nil
+	}
+	return ring.since(lastSeq, uptoSeq)
+}
+
+// Head returns the sequence number of the most recently appended event
+// for ownerID, or 0 if none has been appended yet.
+func (r *Registry) Head(ownerID uuid.UUID) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.buffers[ownerID]
+	if !ok {
+		return 0
+	}
+	return ring.next
+}
+
+// ownerRing is a fixed-capacity circular buffer of entries for a single
+// owner.
+type ownerRing struct {
+	entries [ringSize]BufferedEvent
+	next    int64 // sequence number of the most recently appended entry, 0 before the first append
+	count   int   // number of valid entries currently buffered
+}
+
+func (o *ownerRing) append(event pubsub.ChatEvent) int64 {
+	o.next++
+	seq := o.next
+	o.entries[seq%ringSize] = BufferedEvent{Seq: seq, Event: event}
+	if o.count < ringSize {
+		o.count++
+	}
+	return seq
+}
+
+func (o *ownerRing) since(lastSeq, uptoSeq int64) []BufferedEvent {
+	if o.count == 0 || uptoSeq > o.next {
+		uptoSeq = o.next
+	}
+
+	oldest := o.next - int64(o.count) + 1
+	if lastSeq < oldest-1 {
+		lastSeq = oldest - 1
+	}
+
+	var out []BufferedEvent
+	for seq := lastSeq + 1; seq <= uptoSeq; seq++ {
+		e := o.entries[seq%ringSize]
+		if e.Seq == seq {
+			out = append(out, e)
+		}
+	}
+	return out
+}
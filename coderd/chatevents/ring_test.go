@@ -0,0 +1,68 @@
+package chatevents
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/pubsub"
+)
+
+func TestRegistry_AppendAndSince(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	owner := uuid.New()
+
+	var seqs []int64
+	for i := 0; i < 5; i++ {
+		seqs = append(seqs, r.Append(owner, pubsub.ChatEvent{Kind: pubsub.ChatEventKindCreated}))
+	}
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, seqs)
+	require.Equal(t, int64(5), r.Head(owner))
+
+	// Since(2, 5) should return seq 3, 4, 5 only.
+	got := r.Since(owner, 2, 5)
+	require.Len(t, got, 3)
+	require.Equal(t, []int64{3, 4, 5}, bufferedSeqs(got))
+
+	// An uptoSeq no higher than the client's lastSeq yields nothing.
+	require.Empty(t, r.Since(owner, 5, 5))
+}
+
+func TestRegistry_SinceClampsGapToOldestBuffered(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	owner := uuid.New()
+
+	// Append more than ringSize events so early ones are overwritten.
+	for i := 0; i < ringSize+10; i++ {
+		r.Append(owner, pubsub.ChatEvent{Kind: pubsub.ChatEventKindCreated})
+	}
+
+	// A client that last saw seq 1 (long since evicted) should get
+	// everything still buffered, not an error or a panic, starting from
+	// the oldest surviving sequence number.
+	got := r.Since(owner, 1, int64(ringSize+10))
+	require.Len(t, got, ringSize)
+	require.Equal(t, int64(11), got[0].Seq)
+	require.Equal(t, int64(ringSize+10), got[len(got)-1].Seq)
+}
+
+func TestRegistry_UnknownOwner(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	require.Nil(t, r.Since(uuid.New(), 0, 100))
+	require.Equal(t, int64(0), r.Head(uuid.New()))
+}
+
+func bufferedSeqs(events []BufferedEvent) []int64 {
+	seqs := make([]int64, len(events))
+	for i, e := range events {
+		seqs[i] = e.Seq
+	}
+	return seqs
+}
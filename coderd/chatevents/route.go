@@ -0,0 +1,23 @@
+package chatevents
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// RegisterRoutes mounts the chat events SSE stream at
+// GET /chats/events under r, intended to be called from within
+// coderd's existing /api/v2/users/{user} subrouter:
+//
+//	r.Route("/users/{user}", func(r chi.Router) {
+//		r.Use(httpmw.ExtractUserParam(options.Database))
+//		chatevents.RegisterRoutes(r, hub, func(r *http.Request) (uuid.UUID, error) {
+//			return httpmw.UserParam(r).ID, nil
+//		})
+//	})
+func RegisterRoutes(r chi.Router, hub *Hub, resolveOwnerID func(r *http.Request) (uuid.UUID, error)) {
+	handler := &Handler{Hub: hub, ResolveOwnerID: resolveOwnerID}
+	r.Get("/chats/events", handler.ServeHTTP)
+}
@@ -0,0 +1,95 @@
+package chatevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Handler serves the chat events SSE stream, mounted at
+// GET /api/v2/users/{user}/chats/events by RegisterRoutes. ResolveOwnerID
+// maps an incoming request to the chat owner it is authorized to read,
+// e.g. httpmw.UserParam(r).ID.
+type Handler struct {
+	Hub            *Hub
+	ResolveOwnerID func(r *http.Request) (uuid.UUID, error)
+}
+
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	ownerID, err := h.ResolveOwnerID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastSeq, err := parseLastEventID(r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid Last-Event-ID: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	ch, cutoffSeq, unsubscribe, err := h.Hub.Subscribe(ownerID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+
+	// Replay anything buffered since the client's last seen sequence, up
+	// to (and including) cutoffSeq, before switching to live delivery.
+	// cutoffSeq was captured atomically with subscribing above, so
+	// events are delivered exactly once: anything at or before it came
+	// from this replay, anything after arrives on ch.
+	for _, buffered := range h.Hub.ring.Since(ownerID, lastSeq, cutoffSeq) {
+		if !writeEvent(rw, buffered) {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case buffered, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !writeEvent(rw, buffered) {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(rw http.ResponseWriter, buffered BufferedEvent) bool {
+	data, err := json.Marshal(buffered.Event)
+	if err != nil {
+		return false
+	}
+	_, err = fmt.Fprintf(rw, "id: %d\ndata: %s\n\n", buffered.Seq, data)
+	return err == nil
+}
+
+func parseLastEventID(header string) (int64, error) {
+	if header == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(header, 10, 64)
+}
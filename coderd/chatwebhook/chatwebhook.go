@@ -0,0 +1,121 @@
+// Package chatwebhook fans ChatEvents out to user-registered HTTP
+// webhook endpoints. It subscribes to ChatEventChannel(ownerID) for
+// every owner that has at least one enabled webhook and delivers each
+// event with an HTTP Signature so recipients can verify authenticity.
+package chatwebhook
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/coderd/database/pubsub"
+	chatpubsub "github.com/coder/coder/v2/coderd/pubsub"
+)
+
+// Dispatcher subscribes to chat events on behalf of every owner with
+// registered webhooks and fans each event out to them.
+type Dispatcher struct {
+	logger     slog.Logger
+	ps         pubsub.Pubsub
+	store      Store
+	httpClient *http.Client
+
+	mu   sync.Mutex
+	subs map[uuid.UUID]func()
+}
+
+// New constructs a Dispatcher. Call Start to begin subscribing.
+func New(logger slog.Logger, ps pubsub.Pubsub, store Store) *Dispatcher {
+	return &Dispatcher{
+		logger:     logger,
+		ps:         ps,
+		store:      store,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		subs:       make(map[uuid.UUID]func()),
+	}
+}
+
+// Start subscribes to every owner that currently has an enabled
+// webhook. It should be called once during coderd startup.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	owners, err := d.store.GetOwnerIDsWithEnabledChatWebhooks(ctx)
+	if err != nil {
+		return xerrors.Errorf("get owners with chat webhooks: %w", err)
+	}
+	for _, ownerID := range owners {
+		d.SubscribeOwner(ownerID)
+	}
+	return nil
+}
+
+// Close cancels every active subscription.
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ownerID, cancel := range d.subs {
+		cancel()
+		delete(d.subs, ownerID)
+	}
+	return nil
+}
+
+// SubscribeOwner starts fanning out events for ownerID if it isn't
+// already being watched. Call this whenever an owner registers their
+// first webhook so delivery starts without a coderd restart.
+func (d *Dispatcher) SubscribeOwner(ownerID uuid.UUID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.subs[ownerID]; ok {
+		return
+	}
+
+	cancel, err := d.ps.SubscribeWithErr(chatpubsub.ChatEventChannel(ownerID), chatpubsub.HandleChatEvent(d.store,
+		func(ctx context.Context, event chatpubsub.ChatEvent, err error) {
+			if err != nil {
+				d.logger.Warn(ctx, "chat webhook dispatcher received error", "error", err)
+				return
+			}
+			d.fanOut(ctx, ownerID, event)
+		},
+	))
+	if err != nil {
+		d.logger.Warn(context.Background(), "subscribe chat webhook owner", "owner_id", ownerID, "error", err)
+		return
+	}
+	d.subs[ownerID] = cancel
+}
+
+// UnsubscribeOwner stops fanning out events for ownerID. Call this once
+// an owner's last webhook is deleted or disabled.
+func (d *Dispatcher) UnsubscribeOwner(ownerID uuid.UUID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cancel, ok := d.subs[ownerID]
+	if !ok {
+		return
+	}
+	cancel()
+	delete(d.subs, ownerID)
+}
+
+func (d *Dispatcher) fanOut(ctx context.Context, ownerID uuid.UUID, event chatpubsub.ChatEvent) {
+	webhooks, err := d.store.GetEnabledChatWebhooksByOwnerID(ctx, ownerID)
+	if err != nil {
+		d.logger.Warn(ctx, "list chat webhooks for owner", "owner_id", ownerID, "error", err)
+		return
+	}
+	for _, webhook := range webhooks {
+		webhook := webhook
+		go func() {
+			if err := d.deliver(ctx, webhook, event); err != nil {
+				d.logger.Warn(ctx, "deliver chat webhook", "webhook_id", webhook.ID, "error", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,125 @@
+package chatwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+	chatpubsub "github.com/coder/coder/v2/coderd/pubsub"
+)
+
+// maxDeliveryAttempts bounds the exponential backoff retry loop. A
+// webhook that keeps failing past this point is given up on; each of
+// its attempts stays recorded via Store.InsertChatWebhookDelivery.
+const maxDeliveryAttempts = 8
+
+// deliveryBackoff returns the delay to wait after failed attempt N
+// (1-indexed) before the next one, doubling each time starting at 1s
+// and capping at 5 minutes.
+func deliveryBackoff(attempt int) time.Duration {
+	d := time.Second << uint(attempt-1)
+	if d > 5*time.Minute || d <= 0 {
+		return 5 * time.Minute
+	}
+	return d
+}
+
+// deliver POSTs event to webhook.URL, retrying with exponential backoff
+// on non-2xx responses or transport errors, and persists every attempt
+// via store for later inspection or replay.
+func (d *Dispatcher) deliver(ctx context.Context, webhook codersdk.ChatWebhook, event chatpubsub.ChatEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return xerrors.Errorf("marshal chat event: %w", err)
+	}
+
+	key, err := uuid.NewRandom()
+	if err != nil {
+		return xerrors.Errorf("generate idempotency key: %w", err)
+	}
+	idempotencyKey := key.String()
+
+	signingKey, err := d.store.GetChatWebhookSigningKey(ctx)
+	if err != nil {
+		return xerrors.Errorf("get chat webhook signing key: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxDeliveryAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(deliveryBackoff(attempt - 1)):
+			}
+		}
+
+		status, deliverErr := d.attempt(ctx, webhook, payload, idempotencyKey, event.Kind, signingKey)
+		record := codersdk.ChatWebhookDelivery{
+			ID:             uuid.New(),
+			ChatWebhookID:  webhook.ID,
+			IdempotencyKey: idempotencyKey,
+			Attempt:        int32(attempt),
+			ResponseStatus: int32(status),
+		}
+		if deliverErr != nil {
+			record.Status = codersdk.ChatWebhookDeliveryStatusFailed
+			record.Error = deliverErr.Error()
+		} else {
+			record.Status = codersdk.ChatWebhookDeliveryStatusSuccess
+		}
+		if err := d.store.InsertChatWebhookDelivery(ctx, record); err != nil {
+			d.logger.Warn(ctx, "insert chat webhook delivery", "error", err)
+		}
+
+		if deliverErr == nil {
+			return nil
+		}
+		lastErr = deliverErr
+	}
+
+	return xerrors.Errorf("deliver chat webhook after %d attempts: %w", maxDeliveryAttempts, lastErr)
+}
+
+// attempt performs a single signed POST and returns the response status
+// code (0 if the request never got a response).
+func (d *Dispatcher) attempt(ctx context.Context, webhook codersdk.ChatWebhook, payload []byte, idempotencyKey string, kind chatpubsub.ChatEventKind, signingKey SigningKey) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, xerrors.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Coder-Event", string(kind))
+	req.Header.Set("X-Coder-Idempotency-Key", idempotencyKey)
+
+	if err := signingKey.Sign(req, payload); err != nil {
+		return 0, xerrors.Errorf("sign request: %w", err)
+	}
+
+	// webhook.Secret gives recipients a cheaper alternative to verifying
+	// the full HTTP Signature: an HMAC-SHA256 of the body they can check
+	// against a value they saved at registration time.
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write(payload)
+	req.Header.Set("X-Coder-Webhook-Secret-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	res, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, xerrors.Errorf("webhook endpoint responded %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
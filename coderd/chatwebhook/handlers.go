@@ -0,0 +1,209 @@
+package chatwebhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// Handlers implements the CRUD API on /api/v2/users/{user}/chat-webhooks
+// described by codersdk's ChatWebhook client methods, and keeps
+// Dispatcher's subscriptions in sync as webhooks are registered and
+// removed.
+type Handlers struct {
+	Store      Store
+	Dispatcher *Dispatcher
+	// ResolveOwnerID maps an incoming request to the chat owner it is
+	// authorized to manage webhooks for, e.g. httpmw.UserParam(r).ID
+	// once this is wired into coderd's router.
+	ResolveOwnerID func(r *http.Request) (uuid.UUID, error)
+}
+
+// Routes returns a chi.Router implementing the chat webhook CRUD API,
+// ready to be mounted at /api/v2/users/{user}/chat-webhooks.
+func (h *Handlers) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.list)
+	r.Post("/", h.create)
+	r.Route("/{chatWebhook}", func(r chi.Router) {
+		r.Patch("/", h.update)
+		r.Delete("/", h.delete)
+	})
+	return r
+}
+
+func (h *Handlers) list(rw http.ResponseWriter, r *http.Request) {
+	ownerID, err := h.ResolveOwnerID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+	webhooks, err := h.Store.ListChatWebhooksByOwnerID(r.Context(), ownerID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(rw, http.StatusOK, redactSecrets(webhooks))
+}
+
+func (h *Handlers) create(rw http.ResponseWriter, r *http.Request) {
+	ownerID, err := h.ResolveOwnerID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var req codersdk.CreateChatWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	webhook := codersdk.ChatWebhook{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		URL:       req.URL,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := h.Store.InsertChatWebhook(r.Context(), webhook); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The new webhook is enabled by default, so start fanning out events
+	// for its owner immediately instead of waiting for a coderd restart.
+	h.Dispatcher.SubscribeOwner(ownerID)
+
+	// Secret is only ever returned here: list/update responses redact it
+	// so it isn't readable again after creation (e.g. via logging or
+	// proxying of those responses).
+	writeJSON(rw, http.StatusCreated, webhook)
+}
+
+func (h *Handlers) update(rw http.ResponseWriter, r *http.Request) {
+	ownerID, err := h.ResolveOwnerID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "chatWebhook"))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.Store.GetChatWebhook(r.Context(), id)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	if webhook.OwnerID != ownerID {
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+
+	var req codersdk.UpdateChatWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Disabled != nil {
+		webhook.Disabled = *req.Disabled
+	}
+
+	if err := h.Store.UpdateChatWebhook(r.Context(), webhook); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.syncSubscription(r, ownerID)
+	writeJSON(rw, http.StatusOK, redactSecret(webhook))
+}
+
+func (h *Handlers) delete(rw http.ResponseWriter, r *http.Request) {
+	ownerID, err := h.ResolveOwnerID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "chatWebhook"))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.Store.GetChatWebhook(r.Context(), id)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	if webhook.OwnerID != ownerID {
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.Store.DeleteChatWebhook(r.Context(), id); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.syncSubscription(r, ownerID)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// syncSubscription stops Dispatcher watching ownerID once they have no
+// enabled webhooks left, undoing SubscribeOwner from create.
+func (h *Handlers) syncSubscription(r *http.Request, ownerID uuid.UUID) {
+	count, err := h.Store.CountEnabledChatWebhooksByOwnerID(r.Context(), ownerID)
+	if err != nil || count > 0 {
+		return
+	}
+	h.Dispatcher.UnsubscribeOwner(ownerID)
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", xerrors.Errorf("generate chat webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// redactSecret clears Secret so it isn't echoed back outside of create,
+// where it is shown once so the recipient can save it.
+func redactSecret(webhook codersdk.ChatWebhook) codersdk.ChatWebhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+func redactSecrets(webhooks []codersdk.ChatWebhook) []codersdk.ChatWebhook {
+	redacted := make([]codersdk.ChatWebhook, len(webhooks))
+	for i, webhook := range webhooks {
+		redacted[i] = redactSecret(webhook)
+	}
+	return redacted
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,151 @@
+package chatwebhook
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// MemStore is an in-memory Store. It is the reference implementation
+// used until the chat_webhooks and chat_webhook_deliveries tables and
+// their sqlc queries land, and is suitable for single-replica
+// deployments and tests; it does not survive a coderd restart and does
+// not work across replicas, unlike a Postgres-backed database.Store.
+type MemStore struct {
+	signingKey SigningKey
+	payloads   PayloadResolver
+
+	mu         sync.Mutex
+	webhooks   map[uuid.UUID]codersdk.ChatWebhook
+	deliveries []codersdk.ChatWebhookDelivery
+}
+
+// PayloadResolver is the subset of chatpubsub.PayloadResolver MemStore
+// needs to implement Store's GetChatEventPayload, so this package
+// doesn't have to import chatpubsub just for one method's argument
+// type.
+type PayloadResolver interface {
+	GetChatEventPayload(ctx context.Context, id uuid.UUID) ([]byte, error)
+}
+
+// NewMemStore returns an empty MemStore signing deliveries with
+// signingKey and resolving oversized payloads via payloads (typically a
+// *chatpubsub.MemPayloadStore shared with the CoalescingPublisher that
+// produced them).
+func NewMemStore(signingKey SigningKey, payloads PayloadResolver) *MemStore {
+	return &MemStore{
+		signingKey: signingKey,
+		payloads:   payloads,
+		webhooks:   make(map[uuid.UUID]codersdk.ChatWebhook),
+	}
+}
+
+func (s *MemStore) InsertChatWebhook(_ context.Context, webhook codersdk.ChatWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (s *MemStore) GetChatWebhook(_ context.Context, id uuid.UUID) (codersdk.ChatWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return codersdk.ChatWebhook{}, xerrors.Errorf("chat webhook %s not found", id)
+	}
+	return webhook, nil
+}
+
+func (s *MemStore) ListChatWebhooksByOwnerID(_ context.Context, ownerID uuid.UUID) ([]codersdk.ChatWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []codersdk.ChatWebhook
+	for _, webhook := range s.webhooks {
+		if webhook.OwnerID == ownerID {
+			out = append(out, webhook)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemStore) UpdateChatWebhook(_ context.Context, webhook codersdk.ChatWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[webhook.ID]; !ok {
+		return xerrors.Errorf("chat webhook %s not found", webhook.ID)
+	}
+	s.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (s *MemStore) DeleteChatWebhook(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.webhooks, id)
+	return nil
+}
+
+func (s *MemStore) CountEnabledChatWebhooksByOwnerID(_ context.Context, ownerID uuid.UUID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int
+	for _, webhook := range s.webhooks {
+		if webhook.OwnerID == ownerID && !webhook.Disabled {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemStore) GetEnabledChatWebhooksByOwnerID(_ context.Context, ownerID uuid.UUID) ([]codersdk.ChatWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []codersdk.ChatWebhook
+	for _, webhook := range s.webhooks {
+		if webhook.OwnerID == ownerID && !webhook.Disabled {
+			out = append(out, webhook)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemStore) GetOwnerIDsWithEnabledChatWebhooks(_ context.Context) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[uuid.UUID]struct{})
+	var out []uuid.UUID
+	for _, webhook := range s.webhooks {
+		if webhook.Disabled {
+			continue
+		}
+		if _, ok := seen[webhook.OwnerID]; ok {
+			continue
+		}
+		seen[webhook.OwnerID] = struct{}{}
+		out = append(out, webhook.OwnerID)
+	}
+	return out, nil
+}
+
+func (s *MemStore) GetChatWebhookSigningKey(_ context.Context) (SigningKey, error) {
+	return s.signingKey, nil
+}
+
+func (s *MemStore) InsertChatWebhookDelivery(_ context.Context, delivery codersdk.ChatWebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+func (s *MemStore) GetChatEventPayload(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	if s.payloads == nil {
+		return nil, xerrors.Errorf("chat event payload %s: no payload resolver configured", id)
+	}
+	return s.payloads.GetChatEventPayload(ctx, id)
+}
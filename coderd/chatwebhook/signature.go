@@ -0,0 +1,87 @@
+package chatwebhook
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// SigningKey signs outbound webhook deliveries so recipients can verify
+// that a payload really came from this deployment, the same way
+// ActivityPub servers sign inbox POSTs.
+type SigningKey struct {
+	// KeyID is sent in the Signature header's keyId parameter so the
+	// recipient knows which of our published keys to verify against.
+	KeyID string
+	// Signer is either an ed25519.PrivateKey or an *rsa.PrivateKey.
+	Signer crypto.Signer
+}
+
+// Algorithm returns the HTTP Signatures algorithm name for the
+// underlying key type.
+func (k SigningKey) Algorithm() (string, error) {
+	switch k.Signer.(type) {
+	case ed25519.PrivateKey:
+		return "ed25519", nil
+	case *rsa.PrivateKey:
+		return "rsa-sha256", nil
+	default:
+		return "", xerrors.Errorf("unsupported signing key type %T", k.Signer)
+	}
+}
+
+// Sign attaches Digest, Date (if unset) and Signature headers to req
+// using the draft-cavage/RFC 9421-style "(request-target) host date
+// digest" signing string.
+func (k SigningKey) Sign(req *http.Request, body []byte) error {
+	algo, err := k.Algorithm()
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(body)
+	req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]))
+
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	signingString := fmt.Sprintf(
+		"(request-target): %s %s\nhost: %s\ndate: %s\ndigest: %s",
+		strings.ToLower(req.Method),
+		req.URL.RequestURI(),
+		req.Host,
+		req.Header.Get("Date"),
+		req.Header.Get("Digest"),
+	)
+
+	var sig []byte
+	switch signer := k.Signer.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(signer, []byte(signingString))
+	case *rsa.PrivateKey:
+		hashed := sha256.Sum256([]byte(signingString))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, hashed[:])
+		if err != nil {
+			return xerrors.Errorf("rsa sign: %w", err)
+		}
+	default:
+		return xerrors.Errorf("unsupported signing key type %T", k.Signer)
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="%s",headers="(request-target) host date digest",signature="%s"`,
+		k.KeyID, algo, base64.StdEncoding.EncodeToString(sig),
+	))
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package chatwebhook
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKey_SignEd25519(t *testing.T) {
+	t.Parallel()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key := SigningKey{KeyID: "test-key", Signer: priv}
+
+	body := []byte(`{"kind":"message_completed"}`)
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/hooks/abc", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, key.Sign(req, body))
+
+	wantDigest := sha256.Sum256(body)
+	require.Equal(t, "SHA-256="+base64.StdEncoding.EncodeToString(wantDigest[:]), req.Header.Get("Digest"))
+	require.NotEmpty(t, req.Header.Get("Date"))
+
+	signingString := fmt.Sprintf(
+		"(request-target): %s %s\nhost: %s\ndate: %s\ndigest: %s",
+		"post",
+		"/hooks/abc",
+		req.Host,
+		req.Header.Get("Date"),
+		req.Header.Get("Digest"),
+	)
+
+	sigHeader := req.Header.Get("Signature")
+	require.Regexp(t, regexp.MustCompile(`^keyId="test-key",algorithm="ed25519",headers="\(request-target\) host date digest",signature="[^"]+"$`), sigHeader)
+
+	sig := extractSignature(t, sigHeader)
+	require.True(t, ed25519.Verify(pub, []byte(signingString), sig))
+}
+
+func TestSigningKey_SignRSA(t *testing.T) {
+	t.Parallel()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key := SigningKey{KeyID: "rsa-key", Signer: priv}
+
+	body := []byte(`{"kind":"message_token_delta"}`)
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/hooks/xyz", nil)
+	require.NoError(t, err)
+	req.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+
+	require.NoError(t, key.Sign(req, body))
+
+	// Sign must not overwrite a caller-supplied Date header.
+	require.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", req.Header.Get("Date"))
+
+	sigHeader := req.Header.Get("Signature")
+	require.True(t, strings.Contains(sigHeader, `algorithm="rsa-sha256"`))
+	require.True(t, strings.Contains(sigHeader, `keyId="rsa-key"`))
+}
+
+func TestSigningKey_AlgorithmUnsupportedKey(t *testing.T) {
+	t.Parallel()
+
+	key := SigningKey{KeyID: "bad", Signer: nil}
+	_, err := key.Algorithm()
+	require.Error(t, err)
+}
+
+func extractSignature(t *testing.T, header string) []byte {
+	t.Helper()
+
+	match := regexp.MustCompile(`signature="([^"]+)"`).FindStringSubmatch(header)
+	require.Len(t, match, 2)
+	sig, err := base64.StdEncoding.DecodeString(match[1])
+	require.NoError(t, err)
+	return sig
+}
@@ -0,0 +1,52 @@
+package chatwebhook
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// Store is the persistence surface the CRUD handlers and Dispatcher
+// need. The long-term implementation is database.Store, backed by the
+// chat_webhooks and chat_webhook_deliveries tables; MemStore below is a
+// reference implementation usable until that migration lands and in
+// tests.
+type Store interface {
+	// InsertChatWebhook registers a new webhook.
+	InsertChatWebhook(ctx context.Context, webhook codersdk.ChatWebhook) error
+	// GetChatWebhook fetches a single webhook by ID.
+	GetChatWebhook(ctx context.Context, id uuid.UUID) (codersdk.ChatWebhook, error)
+	// ListChatWebhooksByOwnerID returns every webhook an owner has
+	// registered, enabled or not.
+	ListChatWebhooksByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]codersdk.ChatWebhook, error)
+	// UpdateChatWebhook persists changes to an existing webhook.
+	UpdateChatWebhook(ctx context.Context, webhook codersdk.ChatWebhook) error
+	// DeleteChatWebhook removes a webhook. Past deliveries are left in
+	// place for inspection.
+	DeleteChatWebhook(ctx context.Context, id uuid.UUID) error
+	// CountEnabledChatWebhooksByOwnerID reports how many non-disabled
+	// webhooks remain for ownerID, so callers know whether Dispatcher
+	// should keep watching that owner.
+	CountEnabledChatWebhooksByOwnerID(ctx context.Context, ownerID uuid.UUID) (int, error)
+
+	// GetEnabledChatWebhooksByOwnerID returns every non-disabled webhook
+	// an owner has registered.
+	GetEnabledChatWebhooksByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]codersdk.ChatWebhook, error)
+	// GetOwnerIDsWithEnabledChatWebhooks returns the set of owners that
+	// currently have at least one enabled webhook, so Dispatcher knows
+	// which ChatEventChannel(ownerID) channels to subscribe to on
+	// startup.
+	GetOwnerIDsWithEnabledChatWebhooks(ctx context.Context) ([]uuid.UUID, error)
+	// GetChatWebhookSigningKey returns the deployment's Ed25519 or RSA
+	// signing key.
+	GetChatWebhookSigningKey(ctx context.Context) (SigningKey, error)
+	// InsertChatWebhookDelivery records a single delivery attempt.
+	InsertChatWebhookDelivery(ctx context.Context, delivery codersdk.ChatWebhookDelivery) error
+	// GetChatEventPayload resolves an oversized ChatEvent payload
+	// spilled out of band by a chatpubsub.CoalescingPublisher, so
+	// Dispatcher sees a normal ChatEvent regardless of how it was
+	// published.
+	GetChatEventPayload(ctx context.Context, id uuid.UUID) ([]byte, error)
+}
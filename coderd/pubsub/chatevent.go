@@ -15,17 +15,64 @@ func ChatEventChannel(ownerID uuid.UUID) string {
 	return fmt.Sprintf("chat:owner:%s", ownerID)
 }
 
-func HandleChatEvent(cb func(ctx context.Context, payload ChatEvent, err error)) func(ctx context.Context, message []byte, err error) {
+// PayloadResolver fetches an oversized ChatEvent payload previously
+// spilled out of band by a CoalescingPublisher, keyed by the UUID it
+// published in place of the full event.
+type PayloadResolver interface {
+	GetChatEventPayload(ctx context.Context, id uuid.UUID) ([]byte, error)
+}
+
+// chatEventStub is the wire shape of a NOTIFY payload that has been
+// replaced with an out-of-band reference by a CoalescingPublisher: Kind
+// is preserved so logging/metrics don't need to resolve the ref, but
+// Chat and Message are only available by fetching Ref from a
+// PayloadResolver.
+type chatEventStub struct {
+	Kind ChatEventKind `json:"kind"`
+	Ref  *uuid.UUID    `json:"ref,omitempty"`
+}
+
+// HandleChatEvent adapts a typed ChatEvent callback to the raw
+// pubsub.Listener signature. resolver is consulted whenever the
+// incoming payload is a ref stub rather than an inline event; it may be
+// nil if the caller is certain a CoalescingPublisher is never in front
+// of this subscription.
+func HandleChatEvent(resolver PayloadResolver, cb func(ctx context.Context, payload ChatEvent, err error)) func(ctx context.Context, message []byte, err error) {
 	return func(ctx context.Context, message []byte, err error) {
 		if err != nil {
 			cb(ctx, ChatEvent{}, xerrors.Errorf("chat event pubsub: %w", err))
 			return
 		}
+
+		var stub chatEventStub
+		if err := json.Unmarshal(message, &stub); err != nil {
+			cb(ctx, ChatEvent{}, xerrors.Errorf("unmarshal chat event"))
+			return
+		}
+
+		raw := message
+		if stub.Ref != nil {
+			if resolver == nil {
+				cb(ctx, ChatEvent{}, xerrors.Errorf("chat event %s references payload %s but no resolver is configured", stub.Kind, *stub.Ref))
+				return
+			}
+			resolved, err := resolver.GetChatEventPayload(ctx, *stub.Ref)
+			if err != nil {
+				cb(ctx, ChatEvent{}, xerrors.Errorf("resolve chat event payload %s: %w", *stub.Ref, err))
+				return
+			}
+			raw = resolved
+		}
+
 		var payload ChatEvent
-		if err := json.Unmarshal(message, &payload); err != nil {
+		if err := json.Unmarshal(raw, &payload); err != nil {
 			cb(ctx, ChatEvent{}, xerrors.Errorf("unmarshal chat event"))
 			return
 		}
+		if err := payload.Validate(); err != nil {
+			cb(ctx, ChatEvent{}, xerrors.Errorf("invalid chat event: %w", err))
+			return
+		}
 
 		cb(ctx, payload, err)
 	}
@@ -34,6 +81,51 @@ func HandleChatEvent(cb func(ctx context.Context, payload ChatEvent, err error))
 type ChatEvent struct {
 	Kind ChatEventKind `json:"kind"`
 	Chat codersdk.Chat `json:"chat"`
+
+	// Message is populated for message-level event kinds (anything
+	// returned by ChatEventKind.IsMessageKind) and omitted for coarse
+	// lifecycle events.
+	Message *ChatMessageEvent `json:"message,omitempty"`
+}
+
+// Validate enforces that Message is present if and only if Kind is a
+// message-level kind, so subscribers never have to nil-check based on
+// the kind themselves.
+func (e ChatEvent) Validate() error {
+	if e.Kind.IsMessageKind() && e.Message == nil {
+		return xerrors.Errorf("chat event kind %q requires a message", e.Kind)
+	}
+	if !e.Kind.IsMessageKind() && e.Message != nil {
+		return xerrors.Errorf("chat event kind %q must not carry a message", e.Kind)
+	}
+	return nil
+}
+
+// ChatMessageEvent carries the per-message data for message-level
+// ChatEvents. Content holds the full message body for
+// ChatEventKindMessageAppended and ChatEventKindMessageCompleted, and
+// holds only the newly generated chunk for
+// ChatEventKindMessageTokenDelta.
+type ChatMessageEvent struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+
+	// Seq is monotonically increasing per-chat, starting at 1, so
+	// subscribers can detect gaps (e.g. a dropped pubsub notification)
+	// by checking for skipped values.
+	Seq int64 `json:"seq"`
+
+	// SeqEnd is set by a CoalescingPublisher when several consecutive
+	// ChatEventKindMessageTokenDelta events for this message have been
+	// folded into one: Content is their concatenated chunks, and the
+	// folded range is [Seq, SeqEnd). Zero means this event was not
+	// coalesced and covers the single sequence number Seq.
+	SeqEnd int64 `json:"seq_end,omitempty"`
+
+	// Error is populated for ChatEventKindMessageError and describes why
+	// generation failed.
+	Error string `json:"error,omitempty"`
 }
 
 type ChatEventKind string
@@ -43,4 +135,29 @@ const (
 	ChatEventKindTitleChange  ChatEventKind = "title_change"
 	ChatEventKindCreated      ChatEventKind = "created"
 	ChatEventKindDeleted      ChatEventKind = "deleted"
+
+	// ChatEventKindMessageAppended is emitted when a new message (user or
+	// assistant) is appended to the chat transcript.
+	ChatEventKindMessageAppended ChatEventKind = "message_appended"
+	// ChatEventKindMessageTokenDelta is emitted for each chunk streamed
+	// back from the model while an assistant message is being generated.
+	ChatEventKindMessageTokenDelta ChatEventKind = "message_token_delta"
+	// ChatEventKindMessageCompleted is emitted once with the full
+	// assembled content after streaming finishes.
+	ChatEventKindMessageCompleted ChatEventKind = "message_completed"
+	// ChatEventKindMessageError is emitted in place of
+	// ChatEventKindMessageCompleted if generation fails partway through.
+	ChatEventKindMessageError ChatEventKind = "message_error"
 )
+
+// IsMessageKind reports whether the kind is a message-level event that
+// must carry a ChatMessageEvent, as opposed to a coarse chat lifecycle
+// event.
+func (k ChatEventKind) IsMessageKind() bool {
+	switch k {
+	case ChatEventKindMessageAppended, ChatEventKindMessageTokenDelta, ChatEventKindMessageCompleted, ChatEventKindMessageError:
+		return true
+	default:
+		return false
+	}
+}
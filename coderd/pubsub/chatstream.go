@@ -0,0 +1,135 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// EventPublisher publishes a single ChatEvent on behalf of ownerID. The
+// zero-effort implementation is DirectPublisher, which marshals and
+// calls pubsub.Pubsub.Publish directly; CoalescingPublisher is a
+// drop-in replacement that keeps high-volume streams under Postgres'
+// NOTIFY size limit.
+type EventPublisher interface {
+	Publish(ctx context.Context, ownerID uuid.UUID, event ChatEvent) error
+}
+
+// ChatSequencer hands out the monotonic per-chat sequence numbers
+// ChatMessageEvent.Seq requires. It must outlive any single completion
+// and be shared by every ChatStreamPublisher constructed for the same
+// chat (e.g. stored alongside whatever tracks that chat's message
+// history) so that two assistant turns in the same chat never reuse a
+// sequence number.
+type ChatSequencer struct {
+	mu   sync.Mutex
+	seqs map[uuid.UUID]*atomic.Int64
+}
+
+// NewChatSequencer returns an empty ChatSequencer.
+func NewChatSequencer() *ChatSequencer {
+	return &ChatSequencer{seqs: make(map[uuid.UUID]*atomic.Int64)}
+}
+
+func (s *ChatSequencer) next(chatID uuid.UUID) int64 {
+	s.mu.Lock()
+	counter, ok := s.seqs[chatID]
+	if !ok {
+		counter = &atomic.Int64{}
+		s.seqs[chatID] = counter
+	}
+	s.mu.Unlock()
+	return counter.Add(1)
+}
+
+// ChatStreamPublisher publishes message-level ChatEvents for a single
+// chat completion as it streams from the model, assigning each event a
+// monotonic per-chat sequence number drawn from seqs. Callers should
+// construct one ChatStreamPublisher per in-flight completion, but pass
+// the same ChatSequencer across every completion for a given chat.
+type ChatStreamPublisher struct {
+	pub     EventPublisher
+	seqs    *ChatSequencer
+	ownerID uuid.UUID
+	chatID  uuid.UUID
+
+	mu      sync.Mutex
+	content []byte
+}
+
+// NewChatStreamPublisher returns a ChatStreamPublisher that publishes to
+// ChatEventChannel(ownerID) on behalf of chatID via pub, drawing sequence
+// numbers for chatID from seqs.
+func NewChatStreamPublisher(pub EventPublisher, seqs *ChatSequencer, ownerID, chatID uuid.UUID) *ChatStreamPublisher {
+	return &ChatStreamPublisher{pub: pub, seqs: seqs, ownerID: ownerID, chatID: chatID}
+}
+
+// PublishAppended announces that messageID (role, full content) has been
+// appended to the chat transcript.
+func (p *ChatStreamPublisher) PublishAppended(ctx context.Context, messageID uuid.UUID, role, content string) error {
+	return p.publish(ctx, ChatEventKindMessageAppended, &ChatMessageEvent{
+		MessageID: messageID,
+		Role:      role,
+		Content:   content,
+		Seq:       p.nextSeq(),
+	})
+}
+
+// PublishTokenDelta publishes a single streamed chunk of messageID and
+// appends it to the publisher's running buffer so PublishCompleted can
+// later emit the fully assembled content.
+func (p *ChatStreamPublisher) PublishTokenDelta(ctx context.Context, messageID uuid.UUID, role, delta string) error {
+	p.mu.Lock()
+	p.content = append(p.content, delta...)
+	p.mu.Unlock()
+
+	return p.publish(ctx, ChatEventKindMessageTokenDelta, &ChatMessageEvent{
+		MessageID: messageID,
+		Role:      role,
+		Content:   delta,
+		Seq:       p.nextSeq(),
+	})
+}
+
+// PublishCompleted emits the final event for messageID carrying the full
+// content assembled from every delta passed to PublishTokenDelta.
+func (p *ChatStreamPublisher) PublishCompleted(ctx context.Context, messageID uuid.UUID, role string) error {
+	p.mu.Lock()
+	content := string(p.content)
+	p.mu.Unlock()
+
+	return p.publish(ctx, ChatEventKindMessageCompleted, &ChatMessageEvent{
+		MessageID: messageID,
+		Role:      role,
+		Content:   content,
+		Seq:       p.nextSeq(),
+	})
+}
+
+// PublishError emits ChatEventKindMessageError for messageID in place of
+// PublishCompleted when generation fails partway through.
+func (p *ChatStreamPublisher) PublishError(ctx context.Context, messageID uuid.UUID, role string, cause error) error {
+	return p.publish(ctx, ChatEventKindMessageError, &ChatMessageEvent{
+		MessageID: messageID,
+		Role:      role,
+		Seq:       p.nextSeq(),
+		Error:     cause.Error(),
+	})
+}
+
+func (p *ChatStreamPublisher) nextSeq() int64 {
+	return p.seqs.next(p.chatID)
+}
+
+func (p *ChatStreamPublisher) publish(ctx context.Context, kind ChatEventKind, msg *ChatMessageEvent) error {
+	event := ChatEvent{Kind: kind, Chat: codersdk.Chat{ID: p.chatID}, Message: msg}
+	if err := p.pub.Publish(ctx, p.ownerID, event); err != nil {
+		return xerrors.Errorf("publish chat event: %w", err)
+	}
+	return nil
+}
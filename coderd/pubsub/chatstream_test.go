@@ -0,0 +1,127 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []ChatEvent
+}
+
+func (r *recordingPublisher) Publish(_ context.Context, _ uuid.UUID, event ChatEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingPublisher) snapshot() []ChatEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ChatEvent(nil), r.events...)
+}
+
+func TestChatStreamPublisher_SeqIsMonotonicAcrossCompletions(t *testing.T) {
+	t.Parallel()
+
+	pub := &recordingPublisher{}
+	seqs := NewChatSequencer()
+	ownerID, chatID := uuid.New(), uuid.New()
+
+	first := NewChatStreamPublisher(pub, seqs, ownerID, chatID)
+	require.NoError(t, first.PublishAppended(context.Background(), uuid.New(), "user", "hello"))
+	require.NoError(t, first.PublishCompleted(context.Background(), uuid.New(), "assistant"))
+
+	// A second completion for the same chat (e.g. the next assistant
+	// turn) must not restart the sequence at 1.
+	second := NewChatStreamPublisher(pub, seqs, ownerID, chatID)
+	require.NoError(t, second.PublishAppended(context.Background(), uuid.New(), "user", "again"))
+
+	events := pub.snapshot()
+	require.Len(t, events, 3)
+
+	var seen []int64
+	for _, event := range events {
+		seen = append(seen, event.Message.Seq)
+	}
+	require.Equal(t, []int64{1, 2, 3}, seen)
+}
+
+func TestChatStreamPublisher_SeqIsIndependentPerChat(t *testing.T) {
+	t.Parallel()
+
+	pub := &recordingPublisher{}
+	seqs := NewChatSequencer()
+	ownerID := uuid.New()
+
+	chatA := NewChatStreamPublisher(pub, seqs, ownerID, uuid.New())
+	chatB := NewChatStreamPublisher(pub, seqs, ownerID, uuid.New())
+
+	require.NoError(t, chatA.PublishAppended(context.Background(), uuid.New(), "user", "a1"))
+	require.NoError(t, chatB.PublishAppended(context.Background(), uuid.New(), "user", "b1"))
+	require.NoError(t, chatA.PublishAppended(context.Background(), uuid.New(), "user", "a2"))
+
+	events := pub.snapshot()
+	require.Equal(t, int64(1), events[0].Message.Seq)
+	require.Equal(t, int64(1), events[1].Message.Seq)
+	require.Equal(t, int64(2), events[2].Message.Seq)
+}
+
+func TestChatStreamPublisher_StampsChatID(t *testing.T) {
+	t.Parallel()
+
+	pub := &recordingPublisher{}
+	chatID := uuid.New()
+	p := NewChatStreamPublisher(pub, NewChatSequencer(), uuid.New(), chatID)
+
+	require.NoError(t, p.PublishAppended(context.Background(), uuid.New(), "user", "hi"))
+
+	events := pub.snapshot()
+	require.Len(t, events, 1)
+	require.Equal(t, chatID, events[0].Chat.ID)
+}
+
+func TestChatEvent_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("message kind without message", func(t *testing.T) {
+		t.Parallel()
+		event := ChatEvent{Kind: ChatEventKindMessageAppended}
+		require.Error(t, event.Validate())
+	})
+
+	t.Run("lifecycle kind with message", func(t *testing.T) {
+		t.Parallel()
+		event := ChatEvent{Kind: ChatEventKindCreated, Message: &ChatMessageEvent{MessageID: uuid.New()}}
+		require.Error(t, event.Validate())
+	})
+
+	t.Run("message kind with message", func(t *testing.T) {
+		t.Parallel()
+		event := ChatEvent{Kind: ChatEventKindMessageAppended, Message: &ChatMessageEvent{MessageID: uuid.New()}}
+		require.NoError(t, event.Validate())
+	})
+
+	t.Run("lifecycle kind without message", func(t *testing.T) {
+		t.Parallel()
+		event := ChatEvent{Kind: ChatEventKindCreated}
+		require.NoError(t, event.Validate())
+	})
+}
+
+func TestChatEventKind_IsMessageKind(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, ChatEventKindMessageAppended.IsMessageKind())
+	require.True(t, ChatEventKindMessageTokenDelta.IsMessageKind())
+	require.True(t, ChatEventKindMessageCompleted.IsMessageKind())
+	require.True(t, ChatEventKindMessageError.IsMessageKind())
+	require.False(t, ChatEventKindCreated.IsMessageKind())
+	require.False(t, ChatEventKindStatusChange.IsMessageKind())
+}
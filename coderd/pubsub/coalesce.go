@@ -0,0 +1,239 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/coderd/database/pubsub"
+)
+
+// DefaultOversizePayloadThreshold is chosen comfortably under
+// Postgres' 8000-byte NOTIFY payload limit, leaving headroom for the
+// stub envelope itself and for multi-byte UTF-8 expansion during JSON
+// escaping.
+const DefaultOversizePayloadThreshold = 6000
+
+// DefaultCoalesceWindow is how long CoalescingPublisher buffers
+// consecutive ChatEventKindMessageTokenDelta events for the same
+// message before flushing them as a single combined event.
+const DefaultCoalesceWindow = 50 * time.Millisecond
+
+// PayloadStore persists oversized ChatEvent payloads out of band so
+// only a small stub needs to go over Postgres LISTEN/NOTIFY. The
+// long-term implementation is database.Store, backed by the
+// chat_event_payloads table; MemPayloadStore is a reference
+// implementation usable until that migration lands and in tests.
+type PayloadStore interface {
+	PayloadResolver
+	InsertChatEventPayload(ctx context.Context, id uuid.UUID, payload []byte) error
+	// PruneResolvedChatEventPayloads deletes payload rows older than
+	// olderThan that have already been fetched at least once, returning
+	// how many rows were removed. Intended to be called periodically by
+	// RunPayloadGC.
+	PruneResolvedChatEventPayloads(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// CoalescingMetrics is a point-in-time snapshot of CoalescingPublisher
+// activity.
+type CoalescingMetrics struct {
+	Published        int64
+	Oversized        int64
+	CoalescedDeltas  int64 // individual delta events folded into a batch
+	CoalescedBatches int64 // batches actually published
+	FlushErrors      int64 // window-triggered flushes whose publish failed
+}
+
+// CoalesceRatio returns the fraction of buffered delta events that were
+// saved from being published individually, in [0,1].
+func (m CoalescingMetrics) CoalesceRatio() float64 {
+	if m.CoalescedDeltas == 0 {
+		return 0
+	}
+	return 1 - float64(m.CoalescedBatches)/float64(m.CoalescedDeltas)
+}
+
+// CoalescingPublisher is an EventPublisher that keeps high-volume
+// message_token_delta streams under Postgres' NOTIFY size limit by:
+//
+//  1. batching consecutive deltas for the same message within window
+//     into one event carrying the concatenated content and a
+//     [Seq, SeqEnd) range, and
+//  2. spilling any event whose marshaled JSON still exceeds threshold
+//     to store, publishing only a small {"kind":...,"ref":...} stub.
+//
+// HandleChatEvent transparently resolves (2) back into a full ChatEvent
+// given the same PayloadStore as a PayloadResolver.
+type CoalescingPublisher struct {
+	logger    slog.Logger
+	ps        pubsub.Pubsub
+	store     PayloadStore
+	threshold int
+	window    time.Duration
+
+	mu      sync.Mutex
+	pending map[batchKey]*pendingBatch
+
+	metricsMu sync.Mutex
+	metrics   CoalescingMetrics
+}
+
+type batchKey struct {
+	ownerID   uuid.UUID
+	messageID uuid.UUID
+}
+
+type pendingBatch struct {
+	role    string
+	content []byte
+	seqLo   int64
+	seqHi   int64
+	timer   *time.Timer
+}
+
+// NewCoalescingPublisher constructs a CoalescingPublisher with
+// DefaultOversizePayloadThreshold and DefaultCoalesceWindow.
+func NewCoalescingPublisher(logger slog.Logger, ps pubsub.Pubsub, store PayloadStore) *CoalescingPublisher {
+	return &CoalescingPublisher{
+		logger:    logger,
+		ps:        ps,
+		store:     store,
+		threshold: DefaultOversizePayloadThreshold,
+		window:    DefaultCoalesceWindow,
+		pending:   make(map[batchKey]*pendingBatch),
+	}
+}
+
+// Publish implements EventPublisher.
+func (c *CoalescingPublisher) Publish(ctx context.Context, ownerID uuid.UUID, event ChatEvent) error {
+	if event.Kind == ChatEventKindMessageTokenDelta && event.Message != nil {
+		c.enqueueDelta(ownerID, *event.Message)
+		return nil
+	}
+
+	// Any other message-level event means the stream for this message
+	// is done (completed or errored); flush whatever was buffered first
+	// so subscribers see deltas before the terminal event.
+	if event.Message != nil {
+		if err := c.flush(ctx, ownerID, event.Message.MessageID); err != nil {
+			return err
+		}
+	}
+	return c.publishNow(ctx, ownerID, event)
+}
+
+func (c *CoalescingPublisher) enqueueDelta(ownerID uuid.UUID, msg ChatMessageEvent) {
+	key := batchKey{ownerID: ownerID, messageID: msg.MessageID}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addMetric(func(m *CoalescingMetrics) { m.CoalescedDeltas++ })
+
+	batch, ok := c.pending[key]
+	if !ok {
+		batch = &pendingBatch{role: msg.Role, seqLo: msg.Seq}
+		batch.timer = time.AfterFunc(c.window, func() {
+			if err := c.flush(context.Background(), ownerID, msg.MessageID); err != nil {
+				c.addMetric(func(m *CoalescingMetrics) { m.FlushErrors++ })
+				c.logger.Warn(context.Background(), "flush coalesced chat event batch",
+					"owner_id", ownerID, "message_id", msg.MessageID, "error", err)
+			}
+		})
+		c.pending[key] = batch
+	}
+	batch.content = append(batch.content, msg.Content...)
+	batch.seqHi = msg.Seq + 1
+}
+
+// flush publishes whatever is buffered for messageID, if anything.
+func (c *CoalescingPublisher) flush(ctx context.Context, ownerID, messageID uuid.UUID) error {
+	key := batchKey{ownerID: ownerID, messageID: messageID}
+
+	c.mu.Lock()
+	batch, ok := c.pending[key]
+	if ok {
+		delete(c.pending, key)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	batch.timer.Stop()
+
+	c.addMetric(func(m *CoalescingMetrics) { m.CoalescedBatches++ })
+
+	return c.publishNow(ctx, ownerID, ChatEvent{
+		Kind: ChatEventKindMessageTokenDelta,
+		Message: &ChatMessageEvent{
+			MessageID: messageID,
+			Role:      batch.role,
+			Content:   string(batch.content),
+			Seq:       batch.seqLo,
+			SeqEnd:    batch.seqHi,
+		},
+	})
+}
+
+func (c *CoalescingPublisher) publishNow(ctx context.Context, ownerID uuid.UUID, event ChatEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return xerrors.Errorf("marshal chat event: %w", err)
+	}
+	c.addMetric(func(m *CoalescingMetrics) { m.Published++ })
+
+	if len(payload) <= c.threshold {
+		return c.ps.Publish(ChatEventChannel(ownerID), payload)
+	}
+	c.addMetric(func(m *CoalescingMetrics) { m.Oversized++ })
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return xerrors.Errorf("generate chat event payload id: %w", err)
+	}
+	if err := c.store.InsertChatEventPayload(ctx, id, payload); err != nil {
+		return xerrors.Errorf("insert chat event payload: %w", err)
+	}
+
+	stub, err := json.Marshal(chatEventStub{Kind: event.Kind, Ref: &id})
+	if err != nil {
+		return xerrors.Errorf("marshal chat event stub: %w", err)
+	}
+	return c.ps.Publish(ChatEventChannel(ownerID), stub)
+}
+
+func (c *CoalescingPublisher) addMetric(f func(*CoalescingMetrics)) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	f(&c.metrics)
+}
+
+// Metrics returns a snapshot of coalesce-ratio and oversize-payload
+// counters, suitable for exposing on a metrics endpoint.
+func (c *CoalescingPublisher) Metrics() CoalescingMetrics {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	return c.metrics
+}
+
+// RunPayloadGC periodically prunes resolved chat_event_payloads rows
+// older than retention, until ctx is cancelled. It should be started
+// once as a background goroutine alongside coderd.
+func RunPayloadGC(ctx context.Context, store PayloadStore, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = store.PruneResolvedChatEventPayloads(ctx, time.Now().Add(-retention))
+		}
+	}
+}
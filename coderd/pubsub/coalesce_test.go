@@ -0,0 +1,206 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/v2/coderd/database/pubsub"
+)
+
+type fakePublish struct {
+	channel string
+	payload []byte
+}
+
+type fakePubsub struct {
+	mu        sync.Mutex
+	published []fakePublish
+}
+
+func (f *fakePubsub) Publish(channel string, message []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = append(f.published, fakePublish{channel: channel, payload: append([]byte(nil), message...)})
+	return nil
+}
+
+func (f *fakePubsub) Subscribe(event string, listener pubsub.Listener) (func(), error) {
+	return func() {}, nil
+}
+
+func (f *fakePubsub) SubscribeWithErr(event string, listener pubsub.ListenerWithErr) (func(), error) {
+	return func() {}, nil
+}
+
+func (f *fakePubsub) Close() error { return nil }
+
+func (f *fakePubsub) snapshot() []fakePublish {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakePublish(nil), f.published...)
+}
+
+type fakePayloadStore struct {
+	mu       sync.Mutex
+	payloads map[uuid.UUID][]byte
+}
+
+func newFakePayloadStore() *fakePayloadStore {
+	return &fakePayloadStore{payloads: make(map[uuid.UUID][]byte)}
+}
+
+func (s *fakePayloadStore) InsertChatEventPayload(_ context.Context, id uuid.UUID, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads[id] = payload
+	return nil
+}
+
+func (s *fakePayloadStore) GetChatEventPayload(_ context.Context, id uuid.UUID) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.payloads[id], nil
+}
+
+func (s *fakePayloadStore) PruneResolvedChatEventPayloads(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newTestCoalescingPublisher(t *testing.T, window time.Duration) (*CoalescingPublisher, *fakePubsub) {
+	t.Helper()
+
+	ps := &fakePubsub{}
+	c := NewCoalescingPublisher(slogtest.Make(t, nil), ps, newFakePayloadStore())
+	c.window = window
+	return c, ps
+}
+
+func TestCoalescingPublisher_BatchesDeltasWithinWindow(t *testing.T) {
+	t.Parallel()
+
+	c, ps := newTestCoalescingPublisher(t, 20*time.Millisecond)
+	ownerID, messageID := uuid.New(), uuid.New()
+
+	for i, chunk := range []string{"Hel", "lo, ", "world"} {
+		err := c.Publish(context.Background(), ownerID, ChatEvent{
+			Kind: ChatEventKindMessageTokenDelta,
+			Message: &ChatMessageEvent{
+				MessageID: messageID,
+				Role:      "assistant",
+				Content:   chunk,
+				Seq:       int64(i + 1),
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool { return len(ps.snapshot()) == 1 }, time.Second, time.Millisecond)
+
+	published := ps.snapshot()[0]
+	var got ChatEvent
+	require.NoError(t, json.Unmarshal(published.payload, &got))
+	require.Equal(t, ChatEventKindMessageTokenDelta, got.Kind)
+	require.Equal(t, "Hello, world", got.Message.Content)
+	require.Equal(t, int64(1), got.Message.Seq)
+	require.Equal(t, int64(4), got.Message.SeqEnd)
+
+	metrics := c.Metrics()
+	require.Equal(t, int64(3), metrics.CoalescedDeltas)
+	require.Equal(t, int64(1), metrics.CoalescedBatches)
+	require.InDelta(t, 1-1.0/3.0, metrics.CoalesceRatio(), 0.0001)
+}
+
+func TestCoalescingPublisher_TerminalEventFlushesPendingBatchFirst(t *testing.T) {
+	t.Parallel()
+
+	c, ps := newTestCoalescingPublisher(t, time.Hour)
+	ownerID, messageID := uuid.New(), uuid.New()
+
+	require.NoError(t, c.Publish(context.Background(), ownerID, ChatEvent{
+		Kind: ChatEventKindMessageTokenDelta,
+		Message: &ChatMessageEvent{
+			MessageID: messageID,
+			Role:      "assistant",
+			Content:   "partial",
+			Seq:       1,
+		},
+	}))
+
+	require.NoError(t, c.Publish(context.Background(), ownerID, ChatEvent{
+		Kind: ChatEventKindMessageCompleted,
+		Message: &ChatMessageEvent{
+			MessageID: messageID,
+			Role:      "assistant",
+			Content:   "partial and the rest",
+			Seq:       2,
+		},
+	}))
+
+	published := ps.snapshot()
+	require.Len(t, published, 2)
+
+	var batch ChatEvent
+	require.NoError(t, json.Unmarshal(published[0].payload, &batch))
+	require.Equal(t, ChatEventKindMessageTokenDelta, batch.Kind)
+	require.Equal(t, "partial", batch.Message.Content)
+
+	var completed ChatEvent
+	require.NoError(t, json.Unmarshal(published[1].payload, &completed))
+	require.Equal(t, ChatEventKindMessageCompleted, completed.Kind)
+}
+
+func TestCoalescingPublisher_OversizedPayloadSpillsToStore(t *testing.T) {
+	t.Parallel()
+
+	c, ps := newTestCoalescingPublisher(t, time.Hour)
+	// Large enough for the ~72-byte {"kind":...,"ref":...} stub, far below
+	// the marshaled event.
+	c.threshold = 128
+
+	ownerID := uuid.New()
+	event := ChatEvent{
+		Kind: ChatEventKindMessageAppended,
+		Message: &ChatMessageEvent{
+			MessageID: uuid.New(),
+			Role:      "assistant",
+			Content:   string(make([]byte, 256)),
+			Seq:       1,
+		},
+	}
+
+	require.NoError(t, c.Publish(context.Background(), ownerID, event))
+
+	published := ps.snapshot()
+	require.Len(t, published, 1)
+	require.LessOrEqual(t, len(published[0].payload), c.threshold)
+
+	var stub chatEventStub
+	require.NoError(t, json.Unmarshal(published[0].payload, &stub))
+	require.Equal(t, ChatEventKindMessageAppended, stub.Kind)
+	require.NotNil(t, stub.Ref)
+
+	resolved, err := c.store.GetChatEventPayload(context.Background(), *stub.Ref)
+	require.NoError(t, err)
+
+	var full ChatEvent
+	require.NoError(t, json.Unmarshal(resolved, &full))
+	require.Equal(t, event.Message.MessageID, full.Message.MessageID)
+
+	metrics := c.Metrics()
+	require.Equal(t, int64(1), metrics.Oversized)
+	require.Equal(t, int64(1), metrics.Published)
+}
+
+func TestCoalescingMetrics_CoalesceRatioNoDeltas(t *testing.T) {
+	t.Parallel()
+
+	var m CoalescingMetrics
+	require.Equal(t, float64(0), m.CoalesceRatio())
+}
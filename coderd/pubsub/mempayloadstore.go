@@ -0,0 +1,67 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// MemPayloadStore is an in-memory PayloadStore. It is the reference
+// implementation used by CoalescingPublisher until the
+// chat_event_payloads table and its sqlc queries land, and is suitable
+// for single-replica deployments and tests; it does not survive a
+// coderd restart and does not work across replicas, unlike a
+// Postgres-backed database.Store.
+type MemPayloadStore struct {
+	mu       sync.Mutex
+	payloads map[uuid.UUID]memPayload
+}
+
+type memPayload struct {
+	data       []byte
+	insertedAt time.Time
+	resolved   bool
+}
+
+// NewMemPayloadStore returns an empty MemPayloadStore.
+func NewMemPayloadStore() *MemPayloadStore {
+	return &MemPayloadStore{payloads: make(map[uuid.UUID]memPayload)}
+}
+
+func (s *MemPayloadStore) InsertChatEventPayload(_ context.Context, id uuid.UUID, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads[id] = memPayload{data: payload, insertedAt: timeNow()}
+	return nil
+}
+
+func (s *MemPayloadStore) GetChatEventPayload(_ context.Context, id uuid.UUID) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.payloads[id]
+	if !ok {
+		return nil, xerrors.Errorf("chat event payload %s not found", id)
+	}
+	p.resolved = true
+	s.payloads[id] = p
+	return p.data, nil
+}
+
+func (s *MemPayloadStore) PruneResolvedChatEventPayloads(_ context.Context, olderThan time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pruned int64
+	for id, p := range s.payloads {
+		if p.resolved && p.insertedAt.Before(olderThan) {
+			delete(s.payloads, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// timeNow is a var so tests can stub it out deterministically.
+var timeNow = time.Now
@@ -0,0 +1,30 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database/pubsub"
+)
+
+// DirectPublisher is the trivial EventPublisher: it marshals the event
+// and publishes it to ChatEventChannel(ownerID) verbatim, with no
+// batching or size handling. Use CoalescingPublisher instead for
+// high-volume streams such as token-by-token completion.
+type DirectPublisher struct {
+	PS pubsub.Pubsub
+}
+
+func (d DirectPublisher) Publish(_ context.Context, ownerID uuid.UUID, event ChatEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return xerrors.Errorf("marshal chat event: %w", err)
+	}
+	if err := d.PS.Publish(ChatEventChannel(ownerID), payload); err != nil {
+		return xerrors.Errorf("publish chat event: %w", err)
+	}
+	return nil
+}
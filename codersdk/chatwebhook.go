@@ -0,0 +1,109 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// ChatWebhook is a user-registered HTTP endpoint that receives a
+// fanned-out copy of every ChatEvent published for its owner.
+type ChatWebhook struct {
+	ID        uuid.UUID `json:"id" format:"uuid"`
+	OwnerID   uuid.UUID `json:"owner_id" format:"uuid"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt string    `json:"created_at" format:"date-time"`
+}
+
+type CreateChatWebhookRequest struct {
+	URL string `json:"url" validate:"required,url"`
+}
+
+type UpdateChatWebhookRequest struct {
+	URL      *string `json:"url,omitempty" validate:"omitempty,url"`
+	Disabled *bool   `json:"disabled,omitempty"`
+}
+
+// ChatWebhookDeliveryStatus mirrors the delivery outcome recorded in
+// chat_webhook_deliveries.
+type ChatWebhookDeliveryStatus string
+
+const (
+	ChatWebhookDeliveryStatusPending ChatWebhookDeliveryStatus = "pending"
+	ChatWebhookDeliveryStatusSuccess ChatWebhookDeliveryStatus = "success"
+	ChatWebhookDeliveryStatusFailed  ChatWebhookDeliveryStatus = "failed"
+)
+
+type ChatWebhookDelivery struct {
+	ID             uuid.UUID                 `json:"id" format:"uuid"`
+	ChatWebhookID  uuid.UUID                 `json:"chat_webhook_id" format:"uuid"`
+	IdempotencyKey string                    `json:"idempotency_key"`
+	Status         ChatWebhookDeliveryStatus `json:"status"`
+	Attempt        int32                     `json:"attempt"`
+	ResponseStatus int32                     `json:"response_status,omitempty"`
+	Error          string                    `json:"error,omitempty"`
+	CreatedAt      string                    `json:"created_at" format:"date-time"`
+}
+
+// CreateChatWebhook registers a new outbound webhook for the
+// authenticated user.
+func (c *Client) CreateChatWebhook(ctx context.Context, req CreateChatWebhookRequest) (ChatWebhook, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/users/me/chat-webhooks", req)
+	if err != nil {
+		return ChatWebhook{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return ChatWebhook{}, ReadBodyAsError(res)
+	}
+	var webhook ChatWebhook
+	return webhook, json.NewDecoder(res.Body).Decode(&webhook)
+}
+
+// ChatWebhooks lists every outbound webhook registered by the
+// authenticated user.
+func (c *Client) ChatWebhooks(ctx context.Context) ([]ChatWebhook, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/users/me/chat-webhooks", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var webhooks []ChatWebhook
+	return webhooks, json.NewDecoder(res.Body).Decode(&webhooks)
+}
+
+// UpdateChatWebhook updates the URL or enabled state of a registered
+// webhook.
+func (c *Client) UpdateChatWebhook(ctx context.Context, id uuid.UUID, req UpdateChatWebhookRequest) (ChatWebhook, error) {
+	res, err := c.Request(ctx, http.MethodPatch, "/api/v2/users/me/chat-webhooks/"+id.String(), req)
+	if err != nil {
+		return ChatWebhook{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ChatWebhook{}, ReadBodyAsError(res)
+	}
+	var webhook ChatWebhook
+	return webhook, json.NewDecoder(res.Body).Decode(&webhook)
+}
+
+// DeleteChatWebhook unregisters a webhook. Pending deliveries are left
+// untouched for inspection.
+func (c *Client) DeleteChatWebhook(ctx context.Context, id uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete, "/api/v2/users/me/chat-webhooks/"+id.String(), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}